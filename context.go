@@ -0,0 +1,188 @@
+package log
+
+import "context"
+
+// ctxKey is an unexported type for context keys defined in this package, so
+// they never collide with keys from other packages.
+type ctxKey int
+
+const (
+	loggerCtxKey ctxKey = iota
+	fieldsCtxKey
+)
+
+// WithContext returns a copy of ctx carrying l, retrievable with
+// FromContext. It lets a *Logger be threaded through request scopes instead
+// of passed as an explicit parameter.
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey, l)
+}
+
+// FromContext returns the Logger carried by ctx via WithContext, or nil if
+// none was attached.
+func FromContext(ctx context.Context) *Logger {
+	l, _ := ctx.Value(loggerCtxKey).(*Logger)
+	return l
+}
+
+// WithField returns a copy of ctx with k=v added to the fields that LogCtx
+// (and DebugCtx/InfoCtx/WarnCtx/ErrorCtx) attach to entries logged with it,
+// in addition to any fields already present in ctx. It's meant for
+// request-scoped values (trace IDs, user IDs) that should show up on every
+// log call made with that ctx, without every caller threading them through
+// by hand.
+func WithField(ctx context.Context, k string, v interface{}) context.Context {
+	existing, _ := ctx.Value(fieldsCtxKey).(Fields)
+
+	merged := make(Fields, len(existing)+1)
+	for key, val := range existing {
+		merged[key] = val
+	}
+	merged[k] = v
+
+	return context.WithValue(ctx, fieldsCtxKey, merged)
+}
+
+// fieldsFromContext returns the Fields accumulated in ctx via WithField, or
+// nil if there are none.
+func fieldsFromContext(ctx context.Context) Fields {
+	fields, _ := ctx.Value(fieldsCtxKey).(Fields)
+	return fields
+}
+
+// levelLabel returns the string label used in rendered entries for level.
+func levelLabel(level uint8) string {
+	switch level {
+	case LevelError:
+		return labelError
+	case LevelWarn:
+		return labelWarn
+	case LevelInfo:
+		return labelInfo
+	default:
+		return labelDebug
+	}
+}
+
+// withContextFields returns a Logger carrying the fields accumulated in ctx
+// via WithField, merged on top of l's own fields. It returns l unchanged
+// when ctx carries no such fields.
+func (l *Logger) withContextFields(ctx context.Context) *Logger {
+	ctxFields := fieldsFromContext(ctx)
+	if len(ctxFields) == 0 {
+		return l
+	}
+
+	return l.WithFields(ctxFields)
+}
+
+// LogCtx dispatches an entry at the given level, attaching any fields
+// accumulated in ctx via WithField. msg and fields are combined in the
+// manner of fmt.Print, like Debug/Info/Warn/Error. DebugCtx, InfoCtx,
+// WarnCtx and ErrorCtx don't call LogCtx themselves (each calls
+// createLogEntry directly, same as Debug/Info/Warn/Error do for their
+// level) so that callerInfo's fixed stack depth still lands on the actual
+// call site rather than on one of these wrapper methods.
+func (l *Logger) LogCtx(ctx context.Context, level uint8, msg string, fields ...interface{}) {
+	if !l.canLog(level) {
+		return
+	}
+
+	args := append([]interface{}{msg}, fields...)
+	entry := l.withContextFields(ctx).createLogEntry(levelLabel(level), args...)
+	l.core.dispatch(level, entry)
+}
+
+// DebugCtx dispatches a debug-level entry, attaching any fields accumulated
+// in ctx via WithField. msg and fields are combined in the manner of
+// fmt.Print.
+func (l *Logger) DebugCtx(ctx context.Context, msg string, fields ...interface{}) {
+	if !l.canLog(LevelDebug) {
+		return
+	}
+
+	args := append([]interface{}{msg}, fields...)
+	entry := l.withContextFields(ctx).createLogEntry(labelDebug, args...)
+	l.core.dispatch(LevelDebug, entry)
+}
+
+// InfoCtx dispatches an info-level entry, attaching any fields accumulated
+// in ctx via WithField. msg and fields are combined in the manner of
+// fmt.Print.
+func (l *Logger) InfoCtx(ctx context.Context, msg string, fields ...interface{}) {
+	if !l.canLog(LevelInfo) {
+		return
+	}
+
+	args := append([]interface{}{msg}, fields...)
+	entry := l.withContextFields(ctx).createLogEntry(labelInfo, args...)
+	l.core.dispatch(LevelInfo, entry)
+}
+
+// WarnCtx dispatches a warn-level entry, attaching any fields accumulated in
+// ctx via WithField. msg and fields are combined in the manner of fmt.Print.
+func (l *Logger) WarnCtx(ctx context.Context, msg string, fields ...interface{}) {
+	if !l.canLog(LevelWarn) {
+		return
+	}
+
+	args := append([]interface{}{msg}, fields...)
+	entry := l.withContextFields(ctx).createLogEntry(labelWarn, args...)
+	l.core.dispatch(LevelWarn, entry)
+}
+
+// ErrorCtx dispatches an error-level entry, attaching any fields accumulated
+// in ctx via WithField. msg and fields are combined in the manner of
+// fmt.Print.
+func (l *Logger) ErrorCtx(ctx context.Context, msg string, fields ...interface{}) {
+	if !l.canLog(LevelError) {
+		return
+	}
+
+	args := append([]interface{}{msg}, fields...)
+	entry := l.withContextFields(ctx).createLogEntry(labelError, args...)
+	l.core.dispatch(LevelError, entry)
+}
+
+// Flush forces out any output buffered by l. For a NewSync Logger this
+// flushes its bufio.Writer; for a sink-based Logger it's equivalent to
+// FlushCtx(context.Background()), waiting for every currently queued entry
+// to be written.
+func (l *Logger) Flush() error {
+	return l.FlushCtx(context.Background())
+}
+
+// FlushCtx blocks until every entry currently queued on l's sinks has been
+// written, or until ctx is done, whichever comes first. It's meant for
+// server shutdown paths that want to bound how long they wait for pending
+// log output before moving on to Shutdown, which otherwise blocks
+// unconditionally draining each sink. On a NewSync Logger there is no queue
+// to drain, so this just flushes the underlying bufio.Writer.
+func (l *Logger) FlushCtx(ctx context.Context) error {
+	if l.core.sync != nil {
+		return l.core.sync.flush()
+	}
+
+	acks := make([]chan struct{}, 0, len(l.core.workers))
+
+	for _, w := range l.core.workers {
+		ack := make(chan struct{})
+
+		select {
+		case w.entries <- Entry{ack: ack}:
+			acks = append(acks, ack)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for _, ack := range acks {
+		select {
+		case <-ack:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
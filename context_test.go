@@ -0,0 +1,68 @@
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFlushCtxSucceeds(t *testing.T) {
+	l := New(NewHookSink(func(Entry) {}))
+	defer l.Shutdown()
+
+	l.Info("hello")
+
+	if err := l.FlushCtx(context.Background()); err != nil {
+		t.Fatalf("FlushCtx: %v", err)
+	}
+}
+
+// TestFlushCtxCancellation uses an unbuffered, never-drained channel so
+// FlushCtx's barrier entry is never acknowledged and the call is left to
+// wait on ctx instead.
+func TestFlushCtxCancellation(t *testing.T) {
+	w := &sinkWorker{entries: make(chan Entry)} // unbuffered, nothing reads it
+	core := &loggerCore{workers: []*sinkWorker{w}}
+	l := &Logger{core: core}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := l.FlushCtx(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("FlushCtx = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestWithFieldContext(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithField(ctx, "trace", "t1")
+	ctx = WithField(ctx, "user", "u1")
+
+	got := fieldsFromContext(ctx)
+	want := Fields{"trace": "t1", "user": "u1"}
+
+	if len(got) != len(want) {
+		t.Fatalf("fieldsFromContext = %v, want %v", got, want)
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("fieldsFromContext[%q] = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestWithContextAndFromContext(t *testing.T) {
+	l := New()
+
+	ctx := WithContext(context.Background(), l)
+
+	if got := FromContext(ctx); got != l {
+		t.Errorf("FromContext = %v, want %v", got, l)
+	}
+
+	if got := FromContext(context.Background()); got != nil {
+		t.Errorf("FromContext(no logger) = %v, want nil", got)
+	}
+}
@@ -17,7 +17,7 @@ func Example() {
 	}
 
 	// Start logger, defer proper logger shutdown
-	Log := log.New(file, log.LevelDebug)
+	Log := log.NewFile(file, log.LevelDebug)
 	defer Log.Shutdown()
 
 	Log.Debug("Debug message")
@@ -0,0 +1,122 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// defaultTimeLayout is used by TextFormatter and JSONFormatter when no
+// explicit TimestampFormat is configured.
+const defaultTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// Formatter renders a Entry into the bytes that are written to a
+// Logger's output. Implementations must be safe for concurrent use, since a
+// single Formatter instance is shared by all callers of a Logger.
+type Formatter interface {
+	// Format returns the serialized representation of entry, including any
+	// trailing newline.
+	Format(entry Entry) ([]byte, error)
+}
+
+// TextFormatter renders entries in the historical
+// "[time] [file:line] [level] msg" layout.
+type TextFormatter struct {
+	// TimestampFormat is passed to time.Time.Format. Defaults to
+	// defaultTimeLayout when empty.
+	TimestampFormat string
+
+	// EnableColor, when true, wraps the level label in an ANSI color escape
+	// sequence matching its severity. Callers typically only set this when
+	// the underlying writer is a terminal.
+	EnableColor bool
+}
+
+// level colors, matching the severity ordering in LevelError..LevelDebug.
+var levelColors = map[string]string{
+	labelError: "\x1b[31m", // red
+	labelWarn:  "\x1b[33m", // yellow
+	labelInfo:  "\x1b[36m", // cyan
+	labelDebug: "\x1b[90m", // gray
+}
+
+const colorReset = "\x1b[0m"
+
+// Format implements Formatter.
+func (f *TextFormatter) Format(entry Entry) ([]byte, error) {
+	layout := f.TimestampFormat
+	if layout == "" {
+		layout = defaultTimeLayout
+	}
+
+	level := entry.Level
+	if f.EnableColor {
+		if color, ok := levelColors[entry.Level]; ok {
+			level = color + entry.Level + colorReset
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "[%v] [%v:%v] [%v] %v", entry.Time.Format(layout),
+		entry.Filename, entry.Line, level, entry.Message)
+
+	for k, v := range entry.Fields {
+		fmt.Fprintf(&buf, " %v=%v", k, v)
+	}
+
+	if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// JSONFormatter renders entries as a single JSON object per line, suitable
+// for ingestion by log shippers.
+type JSONFormatter struct {
+	// TimestampFormat is passed to time.Time.Format. Defaults to
+	// defaultTimeLayout when empty.
+	TimestampFormat string
+}
+
+// Format implements Formatter.
+func (f *JSONFormatter) Format(entry Entry) ([]byte, error) {
+	layout := f.TimestampFormat
+	if layout == "" {
+		layout = defaultTimeLayout
+	}
+
+	out := make(map[string]interface{}, len(entry.Fields)+5)
+	for k, v := range entry.Fields {
+		out[k] = v
+	}
+	out["time"] = entry.Time.Format(layout)
+	out["level"] = entry.Level
+	out["file"] = entry.Filename
+	out["line"] = entry.Line
+	out["msg"] = entry.Message
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+// isTerminal reports whether w looks like an interactive terminal. It is
+// used to pick a sensible default for TextFormatter.EnableColor.
+func isTerminal(w interface{}) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,94 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEntry() Entry {
+	return Entry{
+		Level:    labelInfo,
+		Message:  "hello\n",
+		Filename: "widget.go",
+		Line:     42,
+		Time:     time.Date(2026, 7, 27, 10, 30, 0, 0, time.UTC),
+		Fields:   Fields{"req": "abc123"},
+	}
+}
+
+func TestTextFormatterFormat(t *testing.T) {
+	f := &TextFormatter{}
+
+	b, err := f.Format(testEntry())
+	if err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+
+	got := string(b)
+	want := "[2026-07-27T10:30:00.000Z] [widget.go:42] [INFO] hello\n req=abc123\n"
+
+	if got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestTextFormatterEnableColor(t *testing.T) {
+	f := &TextFormatter{EnableColor: true}
+
+	b, err := f.Format(testEntry())
+	if err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+
+	if !strings.Contains(string(b), "\x1b[36mINFO\x1b[0m") {
+		t.Errorf("Format() = %q, want colored level", b)
+	}
+}
+
+func TestJSONFormatterFormat(t *testing.T) {
+	f := &JSONFormatter{}
+
+	b, err := f.Format(testEntry())
+	if err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+
+	got := string(b)
+	for _, want := range []string{
+		`"level":"INFO"`,
+		`"file":"widget.go"`,
+		`"line":42`,
+		`"msg":"hello\n"`,
+		`"req":"abc123"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Format() = %q, want substring %q", got, want)
+		}
+	}
+
+	if !strings.HasSuffix(got, "\n") {
+		t.Errorf("Format() = %q, want trailing newline", got)
+	}
+}
+
+func TestWithFieldsMerge(t *testing.T) {
+	parent := (&Logger{}).WithFields(Fields{"service": "widget", "env": "prod"})
+	child := parent.WithFields(Fields{"env": "staging", "req": "abc123"})
+
+	want := Fields{"service": "widget", "env": "staging", "req": "abc123"}
+
+	if len(child.fields) != len(want) {
+		t.Fatalf("child.fields = %v, want %v", child.fields, want)
+	}
+
+	for k, v := range want {
+		if got := child.fields[k]; got != v {
+			t.Errorf("child.fields[%q] = %v, want %v", k, got, v)
+		}
+	}
+
+	if parent.fields["env"] != "prod" {
+		t.Errorf("parent.fields mutated by child WithFields: %v", parent.fields)
+	}
+}
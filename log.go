@@ -1,9 +1,14 @@
 /*
 Package log is another implementation of logger in golang. It is simple,
-supports log levels and is thread safe. File writing synchronization is achieved
-using channels. Struct fields thread-safety is achieved using locks. It is
-intended to be used in a server application writing logs to a file. Log file
-rotation is on a TODO list (using https://github.com/natefinch/lumberjack)
+supports log levels and is thread safe. A Logger dispatches to one or more
+Sinks (file, stderr, syslog, or custom), each on its own goroutine behind a
+bounded buffer, so a slow sink can't stall the others. Struct fields
+thread-safety is achieved using locks. It is intended to be used in a server
+application writing logs to a file. NewRotatingFile provides built-in,
+size-based log file rotation with optional gzip compression of old backups.
+NewSync offers a lower-latency alternative for callers that don't need
+cross-goroutine ordering: it writes synchronously, serialized on a mutex,
+instead of dispatching through a sink goroutine.
 */
 package log
 
@@ -13,9 +18,14 @@ import (
 	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Fields is a set of key/value pairs attached to a Logger (and therefore to
+// every entry it writes) via WithFields.
+type Fields map[string]interface{}
+
 // Log level output indicator strings
 const (
 	labelDebug = "DEBUG"
@@ -32,258 +42,333 @@ const (
 	LevelDebug
 )
 
-// Logger represents active logger object. It writes log entries to
-// io.WriteCloser. Entries to be written are received trhough entries channel.
-// Logger outputs only entries with level equal to, or lower than level of
-// the Logger. Logger has to be Shutdown to properly close channels and writer.
+// loggerCore holds the mutable, shared state of a Logger. It is split out
+// from Logger so that WithFields can hand out child loggers that share the
+// same sinks without copying locks.
+type loggerCore struct {
+	workers []*sinkWorker // one dispatch goroutine per sink
+	wg      sync.WaitGroup
+
+	// sync, when non-nil, makes this core write synchronously through a
+	// single mutex-guarded bufio.Writer instead of dispatching to workers.
+	// It is set only by NewSync.
+	sync *syncWriter
+
+	verbosity uint32       // global V() verbosity, accessed atomically
+	vmodules  atomic.Value // holds []vmoduleRule, set via SetVModule
+	vcache    sync.Map     // caller PC -> effective verbosity level
+}
+
+// Logger represents active logger object. It dispatches log entries to one
+// or more Sinks, each on its own goroutine, so a slow sink can't stall the
+// others. Logger has to be Shutdown to properly close its sinks.
+// Loggers returned by WithFields share the same sinks as their parent; only
+// the attached Fields differ.
 type Logger struct {
-	mutex    sync.Mutex     // mutex to sync access to other fields
-	entries  chan logEntry  // channel for log entries to be written
-	done     chan bool      // indicates all log entries were written
-	writer   io.WriteCloser // output writer
-	minLevel uint8          // minimal log level
+	core   *loggerCore
+	fields Fields // context merged into every entry written by this Logger
+}
+
+// New creates a new Logger dispatching to sinks. The logger is immediately
+// active; each sink runs on its own goroutine behind a bounded buffer, so a
+// slow sink (network, syslog) can't stall the others. With no sinks, the
+// returned Logger discards everything but can be safely called from
+// application code.
+func New(sinks ...Sink) *Logger {
+	core := &loggerCore{}
+
+	for _, s := range sinks {
+		core.addSink(s)
+	}
+
+	return &Logger{core: core}
 }
 
-// New creates new Logger object. Created logger is immediately active and can
-// write output to w. Minimal log level is l. If w is nil, logger doesn't write
-// anything but can be safely called from application.
-func New(w io.WriteCloser, l uint8) *Logger {
+// NewFile creates a Logger writing to a single io.WriteCloser at minimal
+// level l, matching the single-writer behavior of earlier versions of this
+// package. If w is nil, the logger discards everything but can be safely
+// called from application code.
+func NewFile(w io.WriteCloser, l uint8) *Logger {
 	if l > LevelDebug {
 		panic(fmt.Sprintf("Log level %v, but maximum allowed is %v",
 			l, LevelDebug))
 	}
 
-	logger := &Logger{
-		entries:  make(chan logEntry, 10),
-		done:     make(chan bool),
-		writer:   w,
-		minLevel: l,
+	if w == nil {
+		return New()
 	}
 
-	go logger.listen()
+	return New(NewFileSink(w, l))
+}
 
-	return logger
+// Shutdown closes logger. It closes every sink's entries channel, waits for
+// each sink's dispatch goroutine to drain and close its sink, and only then
+// returns. Proper usage is to defer Shutdown after Logger creation. On
+// server applications, it is better to call shutdown from an os.Signal
+// handler. Shutdown acts on the shared sinks, so calling it on any Logger
+// returned by WithFields closes them for the parent and all siblings too.
+func (l *Logger) Shutdown() {
+	if l.core.sync != nil {
+		l.core.sync.flush()
+		return
+	}
+
+	for _, w := range l.core.workers {
+		close(w.entries)
+	}
+
+	l.core.wg.Wait()
 }
 
-// channel listening method. Run as goroutine asynchronously. Writes entries
-// to l.writer.
-func (l *Logger) listen() {
-	for {
-		entry, more := <-l.entries
-		if more {
-			fmt.Fprint(l.writer, entry)
-		} else {
-			l.done <- true
-			return
+// SetFormatter replaces the Formatter used by every sink that supports one.
+// It affects the parent Logger and every Logger derived from it via
+// WithFields, since they share the same sinks. Sinks that don't render
+// through a Formatter (e.g. HookSink) are left untouched.
+func (l *Logger) SetFormatter(f Formatter) {
+	if l.core.sync != nil {
+		l.core.sync.setFormatter(f)
+		return
+	}
+
+	for _, w := range l.core.workers {
+		if fs, ok := w.sink.(interface{ SetFormatter(Formatter) }); ok {
+			fs.SetFormatter(f)
 		}
 	}
 }
 
-// Shutdown closes logger. It closes entries channel, waits to for remaining
-// entries to process and closes the writer. Proper usage is to defer Shutdown
-// after Logger creation. On server applications, it is better to call shutdown
-// from os.Signal handler.
-func (l *Logger) Shutdown() {
-	close(l.entries)
-	<-l.done
-
-	if l.writer != nil {
-		l.writer.Close()
+// WithFields returns a child Logger that dispatches to the same sinks, but
+// attaches fields to every entry it logs in addition to any fields already
+// carried by l. Fields from fields take precedence over identically named
+// fields inherited from l.
+func (l *Logger) WithFields(fields Fields) *Logger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
 	}
+
+	return &Logger{core: l.core, fields: merged}
 }
 
-// canLog checks, if entry with particular level can be written and if writer is
-// not nil
+// canLog reports whether at least one sink is willing to accept an entry of
+// the given level.
 func (l *Logger) canLog(level uint8) bool {
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
-	return l.minLevel >= level && l.writer != nil
+	if l.core.sync != nil {
+		return l.core.sync.canLog(level)
+	}
+
+	for _, w := range l.core.workers {
+		if w.sink.Level() >= level {
+			return true
+		}
+	}
+
+	return false
 }
 
-// SetLevel sets new minimal log level for logger. If desired level is higher
-// than maximum allowed, method does nothing (returns without warning)
+// SetLevel sets the minimal log level on every sink. If desired level is
+// higher than maximum allowed, method does nothing (returns without
+// warning).
 func (l *Logger) SetLevel(level uint8) {
 	if level > LevelDebug {
 		return
 	}
 
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
+	if l.core.sync != nil {
+		l.core.sync.setLevel(level)
+		return
+	}
 
-	l.minLevel = level
+	for _, w := range l.core.workers {
+		w.sink.SetLevel(level)
+	}
 }
 
-// Debug sends log entry with debug level to logger's entries channel.
+// Debug dispatches a debug-level entry to the logger's sinks.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Debug(v ...interface{}) {
 	if l.canLog(LevelDebug) {
-		l.entries <- createLogEntry(labelDebug, v...)
+		l.core.dispatch(LevelDebug, l.createLogEntry(labelDebug, v...))
 	}
 }
 
-// Debugf sends log entry with debug level to logger's entries channel.
+// Debugf dispatches a debug-level entry to the logger's sinks.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Debugf(format string, v ...interface{}) {
 	if l.canLog(LevelDebug) {
-		l.entries <- createLogEntryf(labelDebug, format, v...)
+		l.core.dispatch(LevelDebug, l.createLogEntryf(labelDebug, format, v...))
 	}
 }
 
-// Info sends log entry with info level to logger's entries channel.
+// Info dispatches an info-level entry to the logger's sinks.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Info(v ...interface{}) {
 	if l.canLog(LevelInfo) {
-		l.entries <- createLogEntry(labelInfo, v...)
+		l.core.dispatch(LevelInfo, l.createLogEntry(labelInfo, v...))
 	}
 }
 
-// Infof sends log entry with info level to logger's entries channel.
+// Infof dispatches an info-level entry to the logger's sinks.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Infof(format string, v ...interface{}) {
 	if l.canLog(LevelInfo) {
-		l.entries <- createLogEntryf(labelInfo, format, v...)
+		l.core.dispatch(LevelInfo, l.createLogEntryf(labelInfo, format, v...))
 	}
 }
 
-// Warn sends log entry with warn level to logger's entries channel.
+// Warn dispatches a warn-level entry to the logger's sinks.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Warn(v ...interface{}) {
 	if l.canLog(LevelWarn) {
-		l.entries <- createLogEntry(labelWarn, v...)
+		l.core.dispatch(LevelWarn, l.createLogEntry(labelWarn, v...))
 	}
 }
 
-// Warn sends log entry with warn level to logger's entries channel.
+// Warn dispatches a warn-level entry to the logger's sinks.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Warnf(format string, v ...interface{}) {
 	if l.canLog(LevelWarn) {
-		l.entries <- createLogEntryf(labelWarn, format, v...)
+		l.core.dispatch(LevelWarn, l.createLogEntryf(labelWarn, format, v...))
 	}
 }
 
-// Error sends log entry with error level to logger's entries channel.
+// Error dispatches an error-level entry to the logger's sinks.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Error(v ...interface{}) {
 	if l.canLog(LevelError) {
-		l.entries <- createLogEntry(labelError, v...)
+		l.core.dispatch(LevelError, l.createLogEntry(labelError, v...))
 	}
 }
 
-// Errorf sends log entry with error level to logger's entries channel.
+// Errorf dispatches an error-level entry to the logger's sinks.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Errorf(format string, v ...interface{}) {
 	if l.canLog(LevelError) {
-		l.entries <- createLogEntryf(labelError, format, v...)
+		l.core.dispatch(LevelError, l.createLogEntryf(labelError, format, v...))
 	}
 }
 
-// Panic sends log entry with error level to logger's entries channel and
+// Panic dispatches an error-level entry to the logger's sinks and
 // calls panic() with entry's message. Arguments are handled in the manner
 // of fmt.Print
 func (l *Logger) Panic(v ...interface{}) {
-	entry := createLogEntry(labelError, v...)
+	entry := l.createLogEntry(labelError, v...)
 	if l.canLog(LevelError) {
-		l.entries <- entry
+		l.core.dispatch(LevelError, entry)
 	}
 
+	l.flushSync()
 	panic(entry.Message)
 
 }
 
-// Panicf sends log entry with error level to logger's entries channel and
+// Panicf dispatches an error-level entry to the logger's sinks and
 // calls panic() with entry's message. Arguments are handled in the manner
 // of fmt.Printf.
 func (l *Logger) Panicf(format string, v ...interface{}) {
-	entry := createLogEntryf(labelError, format, v...)
+	entry := l.createLogEntryf(labelError, format, v...)
 	if l.canLog(LevelError) {
-		l.entries <- entry
+		l.core.dispatch(LevelError, entry)
 	}
 
+	l.flushSync()
 	panic(entry.Message)
 }
 
-// Fatal sends log entry with error level to logger's entries channel and
+// Fatal dispatches an error-level entry to the logger's sinks and
 // calls os.Exit(1). Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Fatal(v ...interface{}) {
 	if l.canLog(LevelError) {
-		l.entries <- createLogEntry(labelError, v...)
+		l.core.dispatch(LevelError, l.createLogEntry(labelError, v...))
 	}
 
+	l.flushSync()
 	os.Exit(1)
 
 }
 
-// Fatalf sends log entry with error level to logger's entries channel and
+// Fatalf dispatches an error-level entry to the logger's sinks and
 // calls os.Exit(1). Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Fatalf(format string, v ...interface{}) {
 	if l.canLog(LevelError) {
-		l.entries <- createLogEntryf(labelError, format, v...)
+		l.core.dispatch(LevelError, l.createLogEntryf(labelError, format, v...))
 	}
 
+	l.flushSync()
 	os.Exit(1)
 }
 
+// flushSync flushes a NewSync logger's buffered writer so a Fatal or Panic
+// call can't lose its entry to an in-memory buffer that os.Exit or an
+// unrecovered panic never gives the runtime a chance to flush. It is a no-op
+// for sink-based loggers; Fatal/Panic there are still subject to the same
+// race FlushCtx exists to bound, since draining a worker's channel before
+// exiting isn't guaranteed to complete.
+func (l *Logger) flushSync() {
+	if l.core.sync != nil {
+		l.core.sync.flush()
+	}
+}
+
 // DebugEnabled returns true, if logger would print a debug entry
 func (l *Logger) DebugEnabled() bool {
-	return l.minLevel >= LevelDebug
+	return l.canLog(LevelDebug)
 }
 
 // InfoEnabled returns true, if logger would print an info entry
 func (l *Logger) InfoEnabled() bool {
-	return l.minLevel >= LevelInfo
+	return l.canLog(LevelInfo)
 }
 
 // WarnEnabled returns true, if logger would print a warn entry
 func (l *Logger) WarnEnabled() bool {
-	return l.minLevel >= LevelWarn
+	return l.canLog(LevelWarn)
 }
 
-// logEntry struct represents a log message to be written to log file.
-// It contains all data necessary to render message.
-type logEntry struct {
+// Entry represents a single log message dispatched to a Logger's Sinks. It
+// contains all data necessary for a Formatter to render it.
+type Entry struct {
 	Level    string    // level of this entry
 	Message  string    // log message
 	Filename string    // caller's filename
 	Line     int       // caller's line number
 	Time     time.Time // time of log event
-}
-
-// Stringer interface implementation
-func (e logEntry) String() string {
-	var format []byte = []byte("[%v] [%v:%v] [%v] %v")
-
-	// Append end-of-line if caller didn't bother.
-	if len(e.Message) == 0 || e.Message[len(e.Message)-1] != '\n' {
-		format = append(format, '\n')
-	}
+	Fields   Fields    // context fields attached via WithFields
 
-	return fmt.Sprintf(string(format), e.Time, e.Filename, e.Line, e.Level,
-		e.Message)
+	// ack, when set, marks this Entry as a flush barrier rather than a real
+	// message: a sink worker closes it instead of writing once it reaches
+	// the front of the queue. See Logger.FlushCtx.
+	ack chan struct{}
 }
 
 // createLogEntryf is equivalent to createLogEntry, but is using format string
-func createLogEntryf(level, format string, v ...interface{}) logEntry {
+func (l *Logger) createLogEntryf(level, format string, v ...interface{}) Entry {
 	now := time.Now()
 	file, line := callerInfo()
-	return logEntry{
+	return Entry{
 		Level:    level,
 		Message:  fmt.Sprintf(format, v...),
 		Filename: file,
 		Line:     line,
 		Time:     now,
+		Fields:   l.fields,
 	}
 }
 
-// createLogEntry prepares logEntry struct prefilled with appropriate data
-func createLogEntry(level string, v ...interface{}) logEntry {
+// createLogEntry prepares Entry struct prefilled with appropriate data
+func (l *Logger) createLogEntry(level string, v ...interface{}) Entry {
 	now := time.Now()
 	file, line := callerInfo()
-	return logEntry{
+	return Entry{
 		Level:    level,
 		Message:  fmt.Sprint(v...),
 		Filename: file,
 		Line:     line,
 		Time:     now,
+		Fields:   l.fields,
 	}
 }
 
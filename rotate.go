@@ -0,0 +1,314 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateOptions configures a rotating file returned by NewRotatingFile.
+type RotateOptions struct {
+	// MaxSizeBytes is the size a log file is allowed to reach before it is
+	// rotated. Zero disables size-based rotation.
+	MaxSizeBytes int64
+
+	// MaxAgeDays is the maximum age, in days, backups are kept before being
+	// pruned. Zero disables age-based pruning.
+	MaxAgeDays int
+
+	// MaxBackups is the maximum number of old, rotated files to keep. Zero
+	// keeps all of them (subject to MaxAgeDays).
+	MaxBackups int
+
+	// Compress gzips rotated backups in the background once they have been
+	// closed out.
+	Compress bool
+
+	// LocalTime uses the local timezone (instead of UTC) for the timestamp
+	// embedded in rotated backup filenames.
+	LocalTime bool
+}
+
+// rotatingFile is the io.WriteCloser returned by NewRotatingFile. On each
+// Write it rotates the primary file to a timestamped backup once
+// opts.MaxSizeBytes is exceeded, reopens the primary file, and prunes and
+// (optionally) compresses old backups on a background goroutine. It assumes
+// a single writer, matching how a Sink is only ever written from its own
+// dispatch goroutine; RotatingFile.mutex only protects against concurrent
+// callers that bypass that assumption (e.g. manual Write calls) and against
+// HandleSIGHUP forcing a reopen concurrently.
+type rotatingFile struct {
+	mutex sync.Mutex
+	path  string
+	opts  RotateOptions
+	file  *os.File
+	size  int64
+}
+
+// NewRotatingFile opens (creating if necessary) the log file at path and
+// returns an io.WriteCloser that rotates it according to opts. Rotated
+// backups are named "<path>.<timestamp>" and, when opts.Compress is set,
+// further suffixed ".gz" once compressed.
+func NewRotatingFile(path string, opts RotateOptions) io.WriteCloser {
+	r := &rotatingFile{path: path, opts: opts}
+
+	if err := r.openExisting(); err != nil {
+		// Match FileSink/New's "nil writer is safe to use" convention: a
+		// rotatingFile that failed to open its file just discards writes
+		// instead of panicking.
+		r.file = nil
+	}
+
+	return r
+}
+
+// openExisting opens r.path for append, creating it if necessary, and
+// records its current size so rotation decisions survive process restarts.
+func (r *rotatingFile) openExisting() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	r.file = f
+	r.size = info.Size()
+
+	return nil
+}
+
+// Write implements io.Writer. It rotates the file first if appending p would
+// exceed opts.MaxSizeBytes.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.file == nil {
+		return len(p), nil
+	}
+
+	if r.opts.MaxSizeBytes > 0 && r.size+int64(len(p)) > r.opts.MaxSizeBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// Close implements io.Closer.
+func (r *rotatingFile) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	return r.file.Close()
+}
+
+// Reopen closes and reopens the log file at its original path, picking up a
+// file an external tool (e.g. logrotate) may have renamed it to. See
+// HandleSIGHUP.
+func (r *rotatingFile) Reopen() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.file != nil {
+		r.file.Close()
+	}
+
+	return r.openExisting()
+}
+
+// backupTimeFormat produces sortable, filesystem-safe backup suffixes.
+const backupTimeFormat = "20060102-150405"
+
+// nextBackupName returns the "<path>.<timestamp>" backup name for a rotation
+// happening at t, falling back to "<path>.<timestamp>.<n>" for an
+// incrementing n when an earlier rotation within the same second already
+// claimed that name.
+func (r *rotatingFile) nextBackupName(t time.Time) (string, error) {
+	base := fmt.Sprintf("%s.%s", r.path, t.Format(backupTimeFormat))
+
+	for candidate, n := base, 1; ; candidate, n = fmt.Sprintf("%s.%d", base, n), n+1 {
+		_, err := os.Stat(candidate)
+		if os.IsNotExist(err) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// rotate closes the current file, renames it to a timestamped backup,
+// reopens path fresh, and kicks off background compression/pruning of old
+// backups. Caller must hold r.mutex.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if !r.opts.LocalTime {
+		now = now.UTC()
+	}
+
+	backup, err := r.nextBackupName(now)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(r.path, backup); err != nil {
+		return err
+	}
+
+	if err := r.openExisting(); err != nil {
+		return err
+	}
+
+	go r.cleanup(backup)
+
+	return nil
+}
+
+// cleanup gzips the backup just rotated out (if configured) and prunes old
+// backups according to opts.MaxBackups and opts.MaxAgeDays. It runs on its
+// own goroutine so a slow compress/prune never blocks logging.
+func (r *rotatingFile) cleanup(backup string) {
+	if r.opts.Compress {
+		if compressed, err := compressFile(backup); err == nil {
+			backup = compressed
+		}
+	}
+
+	backups, err := r.listBackups()
+	if err != nil {
+		return
+	}
+
+	var toRemove []string
+
+	if r.opts.MaxAgeDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -r.opts.MaxAgeDays)
+		kept := backups[:0]
+
+		for _, b := range backups {
+			if b.modTime.Before(cutoff) {
+				toRemove = append(toRemove, b.path)
+			} else {
+				kept = append(kept, b)
+			}
+		}
+
+		backups = kept
+	}
+
+	if r.opts.MaxBackups > 0 && len(backups) > r.opts.MaxBackups {
+		for _, b := range backups[:len(backups)-r.opts.MaxBackups] {
+			toRemove = append(toRemove, b.path)
+		}
+	}
+
+	for _, p := range toRemove {
+		os.Remove(p)
+	}
+}
+
+// backupFile is a rotated backup's path and modification time.
+type backupFile struct {
+	path    string
+	modTime time.Time
+}
+
+// listBackups returns the rotated backups of r.path, oldest first.
+func (r *rotatingFile) listBackups() ([]backupFile, error) {
+	dir := filepath.Dir(r.path)
+	prefix := filepath.Base(r.path) + "."
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backupFile{
+			path:    filepath.Join(dir, e.Name()),
+			modTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.Before(backups[j].modTime)
+	})
+
+	return backups, nil
+}
+
+// compressFile gzips path into path+".gz" and removes the original,
+// returning the new path.
+func compressFile(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dstPath := path + ".gz"
+
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	gw := gzip.NewWriter(dst)
+
+	if _, err := io.Copy(gw, src); err != nil {
+		gw.Close()
+		dst.Close()
+		os.Remove(dstPath)
+		return "", err
+	}
+
+	if err := gw.Close(); err != nil {
+		dst.Close()
+		os.Remove(dstPath)
+		return "", err
+	}
+
+	if err := dst.Close(); err != nil {
+		os.Remove(dstPath)
+		return "", err
+	}
+
+	os.Remove(path)
+
+	return dstPath, nil
+}
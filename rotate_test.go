@@ -0,0 +1,207 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForBackups polls dir until it contains at least n files matching
+// prefix, or fails the test after a short timeout. cleanup runs on its own
+// goroutine, so tests that rely on it need to wait rather than assert
+// immediately.
+func waitForBackups(t *testing.T, dir, prefix string, n int) []os.DirEntry {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("ReadDir(%q): %v", dir, err)
+		}
+
+		var matched []os.DirEntry
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), prefix) {
+				matched = append(matched, e)
+			}
+		}
+
+		if len(matched) >= n {
+			return matched
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d backups of %q in %q, found %d",
+				n, prefix, dir, len(matched))
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitForGzContents polls dir for a compressed backup matching prefix and
+// returns its decompressed contents once it can be read in full, or fails
+// the test after a short timeout. Compression happens on cleanup's
+// background goroutine, so the ".gz" file can exist but still be mid-write;
+// retrying the read (not just the file's presence) avoids that race.
+func waitForGzContents(t *testing.T, dir, prefix string) []byte {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("ReadDir(%q): %v", dir, err)
+		}
+
+		for _, e := range entries {
+			if !strings.HasPrefix(e.Name(), prefix) || !strings.HasSuffix(e.Name(), ".gz") {
+				continue
+			}
+
+			if b, ok := tryReadGz(filepath.Join(dir, e.Name())); ok {
+				return b
+			}
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for a readable .gz backup of %q in %q", prefix, dir)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// tryReadGz reads and decompresses path, returning ok=false instead of an
+// error if it isn't (yet) a complete gzip stream.
+func tryReadGz(path string) (contents []byte, ok bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, false
+	}
+	defer gr.Close()
+
+	b, err := io.ReadAll(gr)
+	if err != nil {
+		return nil, false
+	}
+
+	return b, true
+}
+
+func TestRotatingFileSizeTrigger(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	rf := NewRotatingFile(path, RotateOptions{MaxSizeBytes: 10})
+	defer rf.Close()
+
+	if _, err := rf.Write([]byte("12345")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if _, err := rf.Write([]byte("1234567")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	waitForBackups(t, dir, "app.log.", 1)
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile(%q): %v", path, err)
+	}
+
+	if string(b) != "1234567" {
+		t.Errorf("current file = %q, want %q", b, "1234567")
+	}
+}
+
+func TestRotatingFileBackupCollision(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	rf := &rotatingFile{path: path}
+	if err := rf.openExisting(); err != nil {
+		t.Fatalf("openExisting: %v", err)
+	}
+	defer rf.Close()
+
+	now := time.Now()
+
+	first, err := rf.nextBackupName(now)
+	if err != nil {
+		t.Fatalf("nextBackupName: %v", err)
+	}
+
+	if err := os.WriteFile(first, []byte("old backup"), 0644); err != nil {
+		t.Fatalf("WriteFile(%q): %v", first, err)
+	}
+
+	second, err := rf.nextBackupName(now)
+	if err != nil {
+		t.Fatalf("nextBackupName: %v", err)
+	}
+
+	if second == first {
+		t.Fatalf("nextBackupName returned the same name twice: %q", second)
+	}
+
+	if second != first+".1" {
+		t.Errorf("second = %q, want %q", second, first+".1")
+	}
+}
+
+func TestRotatingFileMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	rf := NewRotatingFile(path, RotateOptions{MaxSizeBytes: 1, MaxBackups: 1})
+	defer rf.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := rf.Write([]byte("xx")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		time.Sleep(1100 * time.Millisecond) // force distinct backup timestamps
+	}
+
+	backups := waitForBackups(t, dir, "app.log.", 1)
+
+	if len(backups) > 1 {
+		t.Errorf("found %d backups, want at most 1 (MaxBackups)", len(backups))
+	}
+}
+
+func TestRotatingFileCompress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	rf := NewRotatingFile(path, RotateOptions{MaxSizeBytes: 1, Compress: true})
+	defer rf.Close()
+
+	if _, err := rf.Write([]byte("a")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := rf.Write([]byte("b")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	b := waitForGzContents(t, dir, "app.log.")
+
+	if string(b) != "a" {
+		t.Errorf("decompressed backup = %q, want %q", b, "a")
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package log
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// reopener is implemented by writers (such as the one returned by
+// NewRotatingFile) that support being closed and reopened at the same path,
+// e.g. after an external tool like logrotate has renamed the file out from
+// under them.
+type reopener interface {
+	Reopen() error
+}
+
+// HandleSIGHUP arranges for w to be reopened, via its Reopen method, every
+// time the process receives SIGHUP. This lets external log rotation tools
+// (logrotate and friends) work against a file also managed by
+// NewRotatingFile: they rename the file, send SIGHUP, and this goroutine
+// reopens w at the original path. If w doesn't support reopening, this is a
+// no-op. Call the returned stop function to deregister the signal handler.
+func HandleSIGHUP(w io.WriteCloser) (stop func()) {
+	ro, ok := w.(reopener)
+	if !ok {
+		return func() {}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-sigCh:
+				ro.Reopen()
+			case <-done:
+				signal.Stop(sigCh)
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
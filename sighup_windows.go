@@ -0,0 +1,10 @@
+//go:build windows
+
+package log
+
+import "io"
+
+// HandleSIGHUP is a no-op on windows, which has no SIGHUP signal.
+func HandleSIGHUP(w io.WriteCloser) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,239 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sinkBufferSize is the capacity of each sink's entries channel. A sink that
+// falls behind this far has its excess entries dropped rather than stalling
+// the other sinks.
+const sinkBufferSize = 500
+
+// Sink is a destination for log entries. A Logger dispatches to one or more
+// Sinks, each on its own goroutine, so a slow sink (network, syslog) can't
+// stall the others. Implementations must be safe for concurrent use: Write
+// and SetLevel/Level may be called from different goroutines.
+type Sink interface {
+	// Write renders and writes entry. It is only ever called from the sink's
+	// own dispatch goroutine.
+	Write(entry Entry) error
+
+	// Level returns the minimal level this sink currently accepts.
+	Level() uint8
+
+	// SetLevel changes the minimal level this sink accepts. Implementations
+	// should ignore levels above LevelDebug, matching Logger.SetLevel.
+	SetLevel(level uint8)
+
+	// Close releases any resources held by the sink. It is called once, after
+	// the sink's entries channel has been drained.
+	Close() error
+}
+
+// sinkWorker pairs a Sink with the buffered channel and goroutine that feed
+// it.
+type sinkWorker struct {
+	sink    Sink
+	entries chan Entry
+	dropped uint64 // atomic; entries dropped since the last successful write
+}
+
+// addSink starts a dispatch goroutine for s and registers it with c.
+func (c *loggerCore) addSink(s Sink) {
+	w := &sinkWorker{
+		sink:    s,
+		entries: make(chan Entry, sinkBufferSize),
+	}
+
+	c.workers = append(c.workers, w)
+
+	c.wg.Add(1)
+	go w.run(&c.wg)
+}
+
+// dispatch fans entry out to every sink willing to accept level. Delivery is
+// non-blocking: a sink whose buffer is full has the entry dropped and
+// counted instead of stalling the caller. On a NewSync core, entry is
+// instead written directly, serialized on a mutex.
+func (c *loggerCore) dispatch(level uint8, entry Entry) {
+	if c.sync != nil {
+		c.sync.write(entry)
+		return
+	}
+
+	for _, w := range c.workers {
+		if w.sink.Level() < level {
+			continue
+		}
+
+		select {
+		case w.entries <- entry:
+		default:
+			atomic.AddUint64(&w.dropped, 1)
+		}
+	}
+}
+
+// run drains w.entries and writes each one to w.sink until the channel is
+// closed, then closes w.sink. If entries were dropped while the buffer was
+// full, a summary is written as soon as the buffer starts draining again.
+// Flush barrier entries (see Logger.FlushCtx) are acknowledged instead of
+// written.
+func (w *sinkWorker) run(wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for entry := range w.entries {
+		if entry.ack != nil {
+			close(entry.ack)
+			continue
+		}
+
+		if dropped := atomic.SwapUint64(&w.dropped, 0); dropped > 0 {
+			w.sink.Write(droppedSummary(dropped))
+		}
+
+		w.sink.Write(entry)
+	}
+
+	w.sink.Close()
+}
+
+// droppedSummary builds the synthetic warning entry emitted when a sink
+// recovers from a full buffer.
+func droppedSummary(dropped uint64) Entry {
+	return Entry{
+		Level:    labelWarn,
+		Message:  fmt.Sprintf("%d messages dropped\n", dropped),
+		Filename: "log.go",
+		Time:     time.Now(),
+	}
+}
+
+// FileSink writes formatted entries to an io.WriteCloser. It is the default
+// sink used by NewFile and backs StderrSink.
+type FileSink struct {
+	mutex     sync.Mutex
+	writer    io.WriteCloser
+	level     uint8
+	formatter Formatter
+}
+
+// NewFileSink creates a Sink writing to w at minimal level level, rendered
+// with a TextFormatter (colored automatically when w is a terminal).
+func NewFileSink(w io.WriteCloser, level uint8) *FileSink {
+	return newFileSink(w, level, isTerminal(w))
+}
+
+// newFileSink builds a FileSink writing to w, with color decided by the
+// caller rather than re-derived from w. NewStderrSink uses this to detect a
+// TTY on the raw os.Stderr before it gets wrapped in nopWriteCloser, which
+// would otherwise hide it from isTerminal's *os.File type assertion.
+func newFileSink(w io.WriteCloser, level uint8, color bool) *FileSink {
+	return &FileSink{
+		writer:    w,
+		level:     level,
+		formatter: &TextFormatter{EnableColor: color},
+	}
+}
+
+// Write implements Sink.
+func (s *FileSink) Write(entry Entry) error {
+	s.mutex.Lock()
+	f := s.formatter
+	s.mutex.Unlock()
+
+	b, err := f.Format(entry)
+	if err != nil {
+		fmt.Fprintf(s.writer, "log: failed to format entry: %v\n", err)
+		return err
+	}
+
+	_, err = s.writer.Write(b)
+	return err
+}
+
+// Level implements Sink.
+func (s *FileSink) Level() uint8 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.level
+}
+
+// SetLevel implements Sink.
+func (s *FileSink) SetLevel(level uint8) {
+	if level > LevelDebug {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.level = level
+}
+
+// SetFormatter replaces the Formatter used to render entries before they are
+// written.
+func (s *FileSink) SetFormatter(f Formatter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.formatter = f
+}
+
+// Close implements Sink. It closes the underlying writer.
+func (s *FileSink) Close() error {
+	return s.writer.Close()
+}
+
+// nopWriteCloser wraps an io.Writer that must not be closed by Sink.Close,
+// such as os.Stderr.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// NewStderrSink creates a Sink writing to os.Stderr at minimal level level.
+// Unlike NewFileSink, closing the returned sink never closes os.Stderr
+// itself.
+func NewStderrSink(level uint8) *FileSink {
+	return newFileSink(nopWriteCloser{os.Stderr}, level, isTerminal(os.Stderr))
+}
+
+// HookFunc is called by a HookSink for every entry it accepts.
+type HookFunc func(entry Entry)
+
+// HookSink fires hook for every error-level entry it receives, regardless of
+// its configured level. It is meant for alerting integrations (Sentry,
+// Slack, PagerDuty) that should only see the entries that matter most.
+type HookSink struct {
+	hook HookFunc
+}
+
+// NewHookSink creates a HookSink calling hook for every error-level entry.
+func NewHookSink(hook HookFunc) *HookSink {
+	return &HookSink{hook: hook}
+}
+
+// Write implements Sink. It calls the hook only for error-level entries.
+func (s *HookSink) Write(entry Entry) error {
+	if entry.Level == labelError {
+		s.hook(entry)
+	}
+
+	return nil
+}
+
+// Level implements Sink. A HookSink always watches error-level entries; this
+// always returns LevelError.
+func (s *HookSink) Level() uint8 { return LevelError }
+
+// SetLevel implements Sink. HookSink's level isn't configurable, so this is a
+// no-op.
+func (s *HookSink) SetLevel(uint8) {}
+
+// Close implements Sink. HookSink holds no resources, so this is a no-op.
+func (s *HookSink) Close() error { return nil }
@@ -0,0 +1,91 @@
+//go:build !windows
+
+package log
+
+import (
+	"log/syslog"
+	"sync"
+)
+
+// SyslogSink writes formatted entries to the local syslog daemon. It isn't
+// available on windows, since the standard library's log/syslog package
+// isn't either.
+type SyslogSink struct {
+	mutex     sync.Mutex
+	writer    *syslog.Writer
+	level     uint8
+	formatter Formatter
+}
+
+// NewSyslogSink opens a connection to the local syslog daemon tagged tag,
+// using priority as the default facility/severity, and returns a Sink
+// writing to it at minimal level level.
+func NewSyslogSink(priority syslog.Priority, tag string, level uint8) (*SyslogSink, error) {
+	w, err := syslog.New(priority, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SyslogSink{
+		writer:    w,
+		level:     level,
+		formatter: &TextFormatter{},
+	}, nil
+}
+
+// Write implements Sink, routing entry to the syslog method matching its
+// level.
+func (s *SyslogSink) Write(entry Entry) error {
+	s.mutex.Lock()
+	f := s.formatter
+	s.mutex.Unlock()
+
+	b, err := f.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	msg := string(b)
+
+	switch entry.Level {
+	case labelError:
+		return s.writer.Err(msg)
+	case labelWarn:
+		return s.writer.Warning(msg)
+	case labelInfo:
+		return s.writer.Info(msg)
+	default:
+		return s.writer.Debug(msg)
+	}
+}
+
+// Level implements Sink.
+func (s *SyslogSink) Level() uint8 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.level
+}
+
+// SetLevel implements Sink.
+func (s *SyslogSink) SetLevel(level uint8) {
+	if level > LevelDebug {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.level = level
+}
+
+// SetFormatter replaces the Formatter used to render entries before they are
+// sent to syslog.
+func (s *SyslogSink) SetFormatter(f Formatter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.formatter = f
+}
+
+// Close implements Sink. It closes the connection to the syslog daemon.
+func (s *SyslogSink) Close() error {
+	return s.writer.Close()
+}
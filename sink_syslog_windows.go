@@ -0,0 +1,14 @@
+//go:build windows
+
+package log
+
+import "errors"
+
+// SyslogSink is unavailable on windows, since the standard library's
+// log/syslog package isn't either.
+type SyslogSink struct{}
+
+// NewSyslogSink always fails on windows. See SyslogSink.
+func NewSyslogSink(priority int, tag string, level uint8) (*SyslogSink, error) {
+	return nil, errors.New("log: SyslogSink is not supported on windows")
+}
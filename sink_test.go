@@ -0,0 +1,102 @@
+package log
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink counts the entries it receives and never errors.
+type recordingSink struct {
+	mutex   sync.Mutex
+	level   uint8
+	entries []Entry
+}
+
+func (s *recordingSink) Write(entry Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *recordingSink) Level() uint8 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.level
+}
+
+func (s *recordingSink) SetLevel(level uint8) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.level = level
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func (s *recordingSink) count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.entries)
+}
+
+func TestDispatchPerSinkLevel(t *testing.T) {
+	errSink := &recordingSink{level: LevelError}
+	debugSink := &recordingSink{level: LevelDebug}
+
+	l := New(errSink, debugSink)
+	defer l.Shutdown()
+
+	l.Debug("debug message")
+	l.Error("error message")
+	l.Flush()
+
+	if got := errSink.count(); got != 1 {
+		t.Errorf("errSink received %d entries, want 1", got)
+	}
+
+	if got := debugSink.count(); got != 2 {
+		t.Errorf("debugSink received %d entries, want 2", got)
+	}
+}
+
+func TestHookSinkOnlyFiresOnError(t *testing.T) {
+	var mutex sync.Mutex
+	var fired []string
+
+	hook := NewHookSink(func(entry Entry) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		fired = append(fired, entry.Message)
+	})
+
+	l := New(hook)
+	defer l.Shutdown()
+
+	l.Warn("not this one")
+	l.Error("this one")
+	l.Flush()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if len(fired) != 1 || fired[0] != "this one" {
+		t.Errorf("hook fired on %v, want exactly [\"this one\"]", fired)
+	}
+}
+
+func TestDroppedSummary(t *testing.T) {
+	e := droppedSummary(3)
+
+	if e.Level != labelWarn {
+		t.Errorf("Level = %q, want %q", e.Level, labelWarn)
+	}
+
+	if e.Message != "3 messages dropped\n" {
+		t.Errorf("Message = %q, want %q", e.Message, "3 messages dropped\n")
+	}
+
+	if time.Since(e.Time) > time.Minute {
+		t.Errorf("Time = %v, want close to now", e.Time)
+	}
+}
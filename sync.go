@@ -0,0 +1,92 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// syncWriter is the synchronous alternative to the sink/dispatch goroutine
+// machinery: Write serializes directly on mutex around a bufio.Writer,
+// instead of allocating an Entry and sending it down a channel to be picked
+// up by another goroutine. It trades the ordering guarantees and isolation
+// a dedicated goroutine gives a slow writer for lower latency and one fewer
+// allocation per call.
+type syncWriter struct {
+	mutex     sync.Mutex
+	buf       *bufio.Writer
+	level     uint8
+	formatter Formatter
+}
+
+// newSyncWriter creates a syncWriter wrapping w at minimal level level.
+func newSyncWriter(w io.Writer, level uint8) *syncWriter {
+	if level > LevelDebug {
+		panic(fmt.Sprintf("Log level %v, but maximum allowed is %v",
+			level, LevelDebug))
+	}
+
+	return &syncWriter{
+		buf:       bufio.NewWriter(w),
+		level:     level,
+		formatter: &TextFormatter{EnableColor: isTerminal(w)},
+	}
+}
+
+// canLog reports whether level is at or below the configured level.
+func (s *syncWriter) canLog(level uint8) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.level >= level
+}
+
+// setLevel changes the minimal level, ignoring values above LevelDebug.
+func (s *syncWriter) setLevel(level uint8) {
+	if level > LevelDebug {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.level = level
+}
+
+// setFormatter replaces the Formatter used to render entries.
+func (s *syncWriter) setFormatter(f Formatter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.formatter = f
+}
+
+// write renders and writes entry into the buffered writer, all under
+// s.mutex so concurrent callers serialize instead of racing.
+func (s *syncWriter) write(entry Entry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	b, err := s.formatter.Format(entry)
+	if err != nil {
+		fmt.Fprintf(s.buf, "log: failed to format entry: %v\n", err)
+		return
+	}
+
+	s.buf.Write(b)
+}
+
+// flush flushes any buffered, unwritten bytes to the underlying writer.
+func (s *syncWriter) flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.buf.Flush()
+}
+
+// NewSync creates a Logger that writes directly to w, serialized by a mutex,
+// instead of dispatching through a sink goroutine. Use it when callers don't
+// need the cross-goroutine ordering New/NewFile provide and want to avoid
+// the per-call Entry allocation and channel send those incur. Output is
+// rendered using a TextFormatter by default; use SetFormatter to change it.
+// Call Flush (or Shutdown) to force buffered output out to w.
+func NewSync(w io.Writer, level uint8) *Logger {
+	return &Logger{core: &loggerCore{sync: newSyncWriter(w, level)}}
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewSyncWriteAndFlush(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := NewSync(&buf, LevelInfo)
+	defer l.Shutdown()
+
+	l.Debug("not logged")
+	l.Info("logged")
+
+	if buf.Len() != 0 {
+		t.Fatalf("buffer has %d bytes before Flush, want 0 (still buffered)", buf.Len())
+	}
+
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	got := buf.String()
+	if strings.Contains(got, "not logged") {
+		t.Errorf("output = %q, want debug message filtered out", got)
+	}
+	if !strings.Contains(got, "logged") {
+		t.Errorf("output = %q, want info message present", got)
+	}
+}
+
+func TestNewSyncSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := NewSync(&buf, LevelError)
+	defer l.Shutdown()
+
+	if l.DebugEnabled() {
+		t.Fatalf("DebugEnabled() = true before SetLevel")
+	}
+
+	l.SetLevel(LevelDebug)
+
+	if !l.DebugEnabled() {
+		t.Errorf("DebugEnabled() = false after SetLevel(LevelDebug)")
+	}
+}
@@ -0,0 +1,255 @@
+package log
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// vmoduleRule is one "pattern=level" entry parsed from a SetVModule spec.
+// pattern is matched against the caller's basename and, for patterns
+// containing a "/", against a matching-length suffix of its full path, so
+// "server.go=2" and "auth/*=3" both work.
+type vmoduleRule struct {
+	pattern string
+	level   uint8
+}
+
+// Verbose is returned by Logger.V. It is a cheap, no-op value when the
+// requested verbosity level isn't enabled for the caller, so call sites can
+// write:
+//
+//	if v := logger.V(2); v.Enabled() {
+//		v.Info("expensive debug dump: ", dump())
+//	}
+type Verbose struct {
+	enabled bool
+	logger  *Logger
+}
+
+// Enabled reports whether this Verbose value will actually log anything.
+func (v Verbose) Enabled() bool {
+	return v.enabled
+}
+
+// Info logs args at debug level if v is enabled. Arguments are handled in
+// the manner of fmt.Print.
+func (v Verbose) Info(args ...interface{}) {
+	if !v.enabled {
+		return
+	}
+
+	v.logger.core.dispatch(LevelDebug, v.logger.createLogEntry(labelDebug, args...))
+}
+
+// Infof logs a formatted message at debug level if v is enabled. Arguments
+// are handled in the manner of fmt.Printf.
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if !v.enabled {
+		return
+	}
+
+	v.logger.core.dispatch(LevelDebug, v.logger.createLogEntryf(labelDebug, format, args...))
+}
+
+// V reports whether verbose logging at the given level is enabled for the
+// caller of V, taking any SetVModule override for the caller's file into
+// account, and returns a Verbose that logs at debug level when so.
+func (l *Logger) V(level uint8) Verbose {
+	if !l.canLog(LevelDebug) {
+		return Verbose{logger: l}
+	}
+
+	pc, _, _, ok := runtime.Caller(1)
+	if !ok {
+		return Verbose{enabled: l.core.globalVerbosity() >= level, logger: l}
+	}
+
+	return Verbose{enabled: l.core.effectiveVerbosity(pc) >= level, logger: l}
+}
+
+// globalVerbosity returns the verbosity level set via SetVerbosity, ignoring
+// any per-file override.
+func (c *loggerCore) globalVerbosity() uint8 {
+	return uint8(atomic.LoadUint32(&c.verbosity))
+}
+
+// effectiveVerbosity resolves the verbosity level that applies to the caller
+// identified by pc, consulting the vcache before doing the (relatively)
+// expensive FuncForPC + pattern matching work.
+func (c *loggerCore) effectiveVerbosity(pc uintptr) uint8 {
+	if cached, ok := c.vcache.Load(pc); ok {
+		return cached.(uint8)
+	}
+
+	level := c.globalVerbosity()
+
+	if rules, ok := c.vmodules.Load().([]vmoduleRule); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			file, _ := fn.FileLine(pc)
+			base := path.Base(file)
+
+			for _, rule := range rules {
+				if matchVModule(rule.pattern, file, base) {
+					level = rule.level
+					break
+				}
+			}
+		}
+	}
+
+	c.vcache.Store(pc, level)
+
+	return level
+}
+
+// clearVCache empties c.vcache in place, one entry at a time, so concurrent
+// Loads/Stores from effectiveVerbosity always see a valid sync.Map rather
+// than racing with a reassignment of the field itself.
+func (c *loggerCore) clearVCache() {
+	c.vcache.Range(func(key, _ interface{}) bool {
+		c.vcache.Delete(key)
+		return true
+	})
+}
+
+// matchVModule reports whether pattern matches the caller's basename, or, for
+// a pattern containing a "/", a path suffix of file with the same number of
+// "/"-separated components (so "auth/*" matches ".../auth/server.go").
+func matchVModule(pattern, file, base string) bool {
+	if ok, err := path.Match(pattern, base); err == nil && ok {
+		return true
+	}
+
+	if !strings.Contains(pattern, "/") {
+		return false
+	}
+
+	patternParts := strings.Split(pattern, "/")
+	fileParts := strings.Split(file, "/")
+
+	if len(patternParts) > len(fileParts) {
+		return false
+	}
+
+	suffix := strings.Join(fileParts[len(fileParts)-len(patternParts):], "/")
+
+	ok, err := path.Match(pattern, suffix)
+	return err == nil && ok
+}
+
+// SetVerbosity sets the global V() verbosity level, used for callers that
+// don't match any SetVModule pattern.
+func (l *Logger) SetVerbosity(level uint8) {
+	atomic.StoreUint32(&l.core.verbosity, uint32(level))
+	l.core.clearVCache()
+}
+
+// SetVModule sets per-file (or per-glob) verbosity overrides from a
+// comma-separated spec of "pattern=level" pairs, e.g.
+// "server.go=2,auth/*=3". Callers whose file doesn't match any pattern fall
+// back to the level set via SetVerbosity.
+func (l *Logger) SetVModule(spec string) error {
+	var rules []vmoduleRule
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("log: invalid vmodule entry %q, want pattern=level", part)
+		}
+
+		level, err := strconv.ParseUint(kv[1], 10, 8)
+		if err != nil {
+			return fmt.Errorf("log: invalid vmodule level in %q: %v", part, err)
+		}
+
+		rules = append(rules, vmoduleRule{pattern: kv[0], level: uint8(level)})
+	}
+
+	l.core.vmodules.Store(rules)
+	l.core.clearVCache()
+
+	return nil
+}
+
+// levelNames maps the string names accepted by SetLevelFromString and
+// RegisterFlags' -loglevel flag to their numeric Level.
+var levelNames = map[string]uint8{
+	"error": LevelError,
+	"warn":  LevelWarn,
+	"info":  LevelInfo,
+	"debug": LevelDebug,
+}
+
+// SetLevelFromString is equivalent to SetLevel, but accepts one of "error",
+// "warn", "info" or "debug" (case-insensitive) instead of a numeric Level.
+func (l *Logger) SetLevelFromString(s string) error {
+	level, ok := levelNames[strings.ToLower(s)]
+	if !ok {
+		return fmt.Errorf("log: unknown level %q", s)
+	}
+
+	l.SetLevel(level)
+
+	return nil
+}
+
+// levelFlag adapts Logger.SetLevelFromString to flag.Value so -loglevel can
+// be registered with the standard flag package.
+type levelFlag struct{ logger *Logger }
+
+// String returns an empty value: with per-sink levels there's no single
+// current level to report, and flag only uses this for -help output.
+func (f levelFlag) String() string { return "" }
+
+func (f levelFlag) Set(s string) error { return f.logger.SetLevelFromString(s) }
+
+// vFlag adapts Logger.SetVerbosity to flag.Value so -v can be registered
+// with the standard flag package.
+type vFlag struct{ logger *Logger }
+
+func (f vFlag) String() string {
+	if f.logger == nil {
+		return "0"
+	}
+	return fmt.Sprintf("%v", f.logger.core.globalVerbosity())
+}
+
+func (f vFlag) Set(s string) error {
+	level, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return err
+	}
+
+	f.logger.SetVerbosity(uint8(level))
+
+	return nil
+}
+
+// vmoduleFlag adapts Logger.SetVModule to flag.Value so -vmodule can be
+// registered with the standard flag package.
+type vmoduleFlag struct{ logger *Logger }
+
+func (f vmoduleFlag) String() string { return "" }
+
+func (f vmoduleFlag) Set(s string) error { return f.logger.SetVModule(s) }
+
+// RegisterFlags registers -v, -vmodule and -loglevel flags on fs that
+// control this Logger's verbosity, per-file verbosity overrides and minimum
+// level respectively. It lets servers wire CLI flags straight to a Logger
+// instead of calling SetVerbosity/SetVModule/SetLevelFromString by hand.
+func (l *Logger) RegisterFlags(fs *flag.FlagSet) {
+	fs.Var(vFlag{l}, "v", "log verbosity level for V() logging")
+	fs.Var(vmoduleFlag{l}, "vmodule",
+		"comma-separated list of pattern=level settings for file-filtered V() logging")
+	fs.Var(levelFlag{l}, "loglevel", "minimum log level (error, warn, info, debug)")
+}
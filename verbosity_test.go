@@ -0,0 +1,134 @@
+package log
+
+import "testing"
+
+func TestSetVModule(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+		rules   []vmoduleRule
+	}{
+		{
+			name:  "single entry",
+			spec:  "server.go=2",
+			rules: []vmoduleRule{{pattern: "server.go", level: 2}},
+		},
+		{
+			name: "multiple entries with whitespace",
+			spec: "server.go=2, auth/*=3",
+			rules: []vmoduleRule{
+				{pattern: "server.go", level: 2},
+				{pattern: "auth/*", level: 3},
+			},
+		},
+		{
+			name:  "empty spec",
+			spec:  "",
+			rules: nil,
+		},
+		{
+			name:  "trailing comma is ignored",
+			spec:  "server.go=2,",
+			rules: []vmoduleRule{{pattern: "server.go", level: 2}},
+		},
+		{
+			name:    "missing level",
+			spec:    "server.go",
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric level",
+			spec:    "server.go=high",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New()
+
+			err := l.SetVModule(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetVModule(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			rules, _ := l.core.vmodules.Load().([]vmoduleRule)
+			if len(rules) != len(tt.rules) {
+				t.Fatalf("rules = %v, want %v", rules, tt.rules)
+			}
+
+			for i, want := range tt.rules {
+				if rules[i] != want {
+					t.Errorf("rules[%d] = %v, want %v", i, rules[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchVModule(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		file    string
+		base    string
+		want    bool
+	}{
+		{
+			name:    "exact basename",
+			pattern: "server.go",
+			file:    "/app/auth/server.go",
+			base:    "server.go",
+			want:    true,
+		},
+		{
+			name:    "basename glob",
+			pattern: "*.go",
+			file:    "/app/auth/server.go",
+			base:    "server.go",
+			want:    true,
+		},
+		{
+			name:    "directory-qualified glob matches path suffix",
+			pattern: "auth/*",
+			file:    "/app/auth/server.go",
+			base:    "server.go",
+			want:    true,
+		},
+		{
+			name:    "directory-qualified glob wrong directory",
+			pattern: "auth/*",
+			file:    "/app/billing/server.go",
+			base:    "server.go",
+			want:    false,
+		},
+		{
+			name:    "pattern longer than path",
+			pattern: "a/b/c/*",
+			file:    "c/server.go",
+			base:    "server.go",
+			want:    false,
+		},
+		{
+			name:    "basename mismatch",
+			pattern: "other.go",
+			file:    "/app/auth/server.go",
+			base:    "server.go",
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchVModule(tt.pattern, tt.file, tt.base); got != tt.want {
+				t.Errorf("matchVModule(%q, %q, %q) = %v, want %v",
+					tt.pattern, tt.file, tt.base, got, tt.want)
+			}
+		})
+	}
+}